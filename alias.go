@@ -0,0 +1,85 @@
+package dmmclust
+
+import "math/rand"
+
+// Alias is a Sampler that implements Vose's alias method. It draws an index
+// from an arbitrary discrete distribution in O(1) time, but Sample pays for
+// that with an O(K) table build - and five K-sized allocations - on every
+// call. The alias method only pays off when the same distribution is
+// sampled many times before it changes; FindClusters calls Sample with a
+// freshly computed probability vector on every iteration, so in this
+// package it is consistently slower than Gibbs (see the benchmarks in
+// alias_test.go), not faster. Prefer Gibbs here; Alias is provided for
+// callers who can amortize the table build across repeated draws from the
+// same p.
+type Alias struct {
+	rand *rand.Rand
+}
+
+// NewAlias creates an Alias sampler that draws its randomness from rand.
+func NewAlias(rand *rand.Rand) *Alias {
+	return &Alias{rand: rand}
+}
+
+// Sample draws an index from p, building a fresh alias table first.
+func (s *Alias) Sample(p []float64) int {
+	prob, alias := newAliasTables(p)
+
+	i := s.rand.Intn(len(p))
+	if s.rand.Float64() < prob[i] {
+		return i
+	}
+	return alias[i]
+}
+
+// newAliasTables builds the prob/alias tables used by Vose's alias method
+// for the discrete distribution p: prob[i] is the probability of staying on
+// i once i has been drawn uniformly, and alias[i] is the index to fall back
+// to otherwise.
+func newAliasTables(p []float64) (prob []float64, alias []int) {
+	k := len(p)
+	prob = make([]float64, k)
+	alias = make([]int, k)
+
+	scaled := make([]float64, k)
+	copy(scaled, p)
+
+	small := make([]int, 0, k)
+	large := make([]int, 0, k)
+	for i := range scaled {
+		scaled[i] *= float64(k)
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		prob[s] = scaled[s]
+		alias[s] = l
+
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// leftover entries are numerically ~1 due to floating point error; treat
+	// them as certainties rather than risk an out-of-range prob.
+	for _, l := range large {
+		prob[l] = 1
+	}
+	for _, s := range small {
+		prob[s] = 1
+	}
+
+	return prob, alias
+}
@@ -0,0 +1,68 @@
+package dmmclust
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAlias_Sample(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	s := NewAlias(r)
+
+	p := []float64{0.1, 0.6, 0.2, 0.1}
+	counts := make([]int, len(p))
+	const draws = 20000
+	for i := 0; i < draws; i++ {
+		counts[s.Sample(p)]++
+	}
+
+	for i, want := range p {
+		got := float64(counts[i]) / draws
+		if got < want-0.02 || got > want+0.02 {
+			t.Errorf("index %d: expected frequency ~%v, got %v", i, want, got)
+		}
+	}
+}
+
+func uniformProbs(k int, r *rand.Rand) []float64 {
+	p := make([]float64, k)
+	var total float64
+	for i := range p {
+		p[i] = r.Float64()
+		total += p[i]
+	}
+	for i := range p {
+		p[i] /= total
+	}
+	return p
+}
+
+func benchmarkSampler(b *testing.B, k int, s Sampler) {
+	r := rand.New(rand.NewSource(1337))
+	p := uniformProbs(k, r)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Sample(p)
+	}
+}
+
+func BenchmarkGibbs_K10(b *testing.B) {
+	benchmarkSampler(b, 10, NewGibbs(rand.New(rand.NewSource(1337))))
+}
+func BenchmarkGibbs_K100(b *testing.B) {
+	benchmarkSampler(b, 100, NewGibbs(rand.New(rand.NewSource(1337))))
+}
+func BenchmarkGibbs_K1000(b *testing.B) {
+	benchmarkSampler(b, 1000, NewGibbs(rand.New(rand.NewSource(1337))))
+}
+
+func BenchmarkAlias_K10(b *testing.B) {
+	benchmarkSampler(b, 10, NewAlias(rand.New(rand.NewSource(1337))))
+}
+func BenchmarkAlias_K100(b *testing.B) {
+	benchmarkSampler(b, 100, NewAlias(rand.New(rand.NewSource(1337))))
+}
+func BenchmarkAlias_K1000(b *testing.B) {
+	benchmarkSampler(b, 1000, NewAlias(rand.New(rand.NewSource(1337))))
+}
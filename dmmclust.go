@@ -1,6 +1,7 @@
 package dmmclust
 
 import (
+	"math"
 	"math/rand"
 	"sync"
 
@@ -38,6 +39,21 @@ type Config struct {
 
 	// Sampler is the sampler function
 	Sampler Sampler
+
+	// Parallel, when true, runs each Gibbs sweep as a per-document worker
+	// pool instead of walking docs serially: workers score and resample
+	// their own shard of docs against a snapshot of the cluster state taken
+	// at the start of the sweep, and the resulting deltas are applied once
+	// the sweep completes. This trades a small amount of staleness in the
+	// statistics for much better throughput when len(docs) is large. It
+	// defaults to false to preserve the existing serial numerics.
+	Parallel bool
+
+	// EstimateHyperparams, when true, re-estimates Alpha and Beta after
+	// every Gibbs sweep using Minka's fixed-point iteration for
+	// Dirichlet-multinomial hyperparameters, instead of holding the
+	// user-supplied values fixed for the whole run.
+	EstimateHyperparams bool
 }
 
 // valid checks that the config for errors
@@ -118,9 +134,21 @@ func (c *Cluster) Words() []int {
 
 // FindClusters is the main function to find clusters.
 func FindClusters(docs []Document, conf Config) ([]Cluster, error) {
-	if err := conf.valid(); err != nil {
+	state, dz, _, err := fit(docs, conf)
+	if err != nil {
 		return nil, err
 	}
+	return relabel(state, dz, conf.K), nil
+}
+
+// fit runs the Gibbs sampling loop shared by FindClusters and Fit, returning
+// the raw, unrelabelled cluster state, the document-to-cluster assignments
+// it converged to, and the (possibly hyperparameter-re-estimated) Config it
+// finished with.
+func fit(docs []Document, conf Config) ([]Cluster, []int, Config, error) {
+	if err := conf.valid(); err != nil {
+		return nil, nil, conf, err
+	}
 	state := make([]Cluster, conf.K)
 	probs := make([]float64, conf.K)
 	for i := range probs {
@@ -141,24 +169,33 @@ func FindClusters(docs []Document, conf Config) ([]Cluster, error) {
 	clusterCount := conf.K
 	for i := 0; i < conf.Iter; i++ {
 		var transfers int
-		for j, doc := range docs {
-			// remove from old cluster
-			old := dz[j]
-			clust := &state[old]
-			clust.removeDoc(doc)
-
-			// draw sample from distro to find new cluster
-			p := conf.Score(doc, docs, state, conf)
-			z2 := conf.Sampler.Sample(p)
-
-			// transfer doc to new clusetr
-			if z2 != old {
-				transfers++
+		if conf.Parallel {
+			transfers = parallelSweep(docs, dz, state, conf)
+		} else {
+			for j, doc := range docs {
+				// remove from old cluster
+				old := dz[j]
+				clust := &state[old]
+				clust.removeDoc(doc)
+
+				// draw sample from distro to find new cluster
+				p := conf.Score(doc, docs, state, conf)
+				z2 := conf.Sampler.Sample(p)
+
+				// transfer doc to new clusetr
+				if z2 != old {
+					transfers++
+				}
+
+				dz[j] = z2
+				newClust := &state[z2]
+				newClust.addDoc(doc)
 			}
+		}
 
-			dz[j] = z2
-			newClust := &state[z2]
-			newClust.addDoc(doc)
+		if conf.EstimateHyperparams {
+			conf.Alpha = estimateAlpha(state, conf.Alpha, len(docs))
+			conf.Beta = estimateBeta(state, conf.Beta, float64(conf.Vocabulary))
 		}
 
 		// TODO: count new clusters
@@ -174,25 +211,39 @@ func FindClusters(docs []Document, conf Config) ([]Cluster, error) {
 		}
 		clusterCount = clusterCount2
 	}
-	// return the clusters. As an additional niceness, we'll relabel the cluster IDs
-	retVal := make([]Cluster, len(dz))
-	reindex := make([]int, conf.K)
+	return state, dz, conf, nil
+}
+
+// clusterLabels renumbers the K internal cluster indices from 0, in order of
+// first appearance in dz, so that callers don't have to care about the
+// (arbitrary, K-sized) internal cluster indices. The returned slice maps an
+// internal index to its label, or -1 if that index never appears in dz.
+func clusterLabels(dz []int, k int) []int {
+	reindex := make([]int, k)
 	for i := range reindex {
 		reindex[i] = -1
 	}
 	var maxID int
-	for i, clusterID := range dz {
-		retVal[i] = state[clusterID]
-		var cid int
-		if cid = reindex[clusterID]; cid < 0 {
-			cid = maxID
+	for _, clusterID := range dz {
+		if reindex[clusterID] < 0 {
+			reindex[clusterID] = maxID
 			maxID++
-			reindex[clusterID] = cid
 		}
+	}
+	return reindex
+}
 
-		retVal[i].id = cid
+// relabel returns the clustering result in the form FindClusters/Fit hand
+// back to callers: one Cluster per document, with cluster IDs renumbered by
+// clusterLabels.
+func relabel(state []Cluster, dz []int, k int) []Cluster {
+	reindex := clusterLabels(dz, k)
+	retVal := make([]Cluster, len(dz))
+	for i, clusterID := range dz {
+		retVal[i] = state[clusterID]
+		retVal[i].id = reindex[clusterID]
 	}
-	return retVal, nil
+	return retVal
 }
 
 /* Scoring Functions */
@@ -260,7 +311,124 @@ func Algorithm4(doc Document, docs []Document, clusters []Cluster, conf Config)
 	for i := range retVal {
 		retVal[i] = retVal[i] / norm
 	}
-	ddd++
+	return retVal
+}
+
+// Algorithm3Log is the log-space equivalent of Algorithm3. Algorithm3 scores
+// clusters as a raw product of per-token probabilities, which underflows to
+// 0 on documents of more than a few dozen tokens (particularly with a small
+// Beta); once every cluster underflows, Algorithm3's zero-norm guard leaves
+// the scores at 0, so the sampler is handed an all-zero vector that says
+// nothing about the document. Algorithm3Log instead
+// accumulates log probabilities and only exponentiates after normalizing
+// with the log-sum-exp trick, so it stays accurate on arbitrarily long
+// documents.
+func Algorithm3Log(doc Document, docs []Document, clusters []Cluster, conf Config) []float64 {
+	docCount := float64(len(docs))
+	k := float64(conf.K)
+	vocab := float64(conf.Vocabulary)
+	logP := make([]float64, len(clusters))
+	var wg sync.WaitGroup
+	ts := doc.TokenSet()
+	for i := range clusters {
+		clust := clusters[i]
+		wg.Add(1)
+		go func(clust Cluster, i int, wg *sync.WaitGroup) {
+			p := math.Log(float64(clust.Docs()) + conf.Alpha/(docCount-1.0+k*conf.Alpha))
+			num := algo3LogNumerator(clust, ts, conf.Beta)
+			denom := algoLogDenominator(clust, ts, conf.Beta, vocab)
+			logP[i] = p + num - denom
+			wg.Done()
+		}(clust, i, &wg)
+	}
+	wg.Wait()
+
+	return normalizeLog(logP)
+}
+
+// Algorithm4Log is the log-space equivalent of Algorithm4, for the case
+// where repeat words are allowed in a document. See Algorithm3Log for why
+// this is necessary.
+func Algorithm4Log(doc Document, docs []Document, clusters []Cluster, conf Config) []float64 {
+	docCount := float64(len(docs))
+	k := float64(conf.K)
+	vocab := float64(conf.Vocabulary)
+	logP := make([]float64, len(clusters))
+	var wg sync.WaitGroup
+	ts := doc.TokenSet()
+	for i := range clusters {
+		clust := clusters[i]
+		wg.Add(1)
+		go func(clust Cluster, i int, wg *sync.WaitGroup) {
+			p := math.Log(float64(clust.Docs()) + conf.Alpha/(docCount-1.0+k*conf.Alpha))
+			num := algo4LogNumerator(clust, ts, conf.Beta)
+			denom := algoLogDenominator(clust, ts, conf.Beta, vocab)
+			logP[i] = p + num - denom
+			wg.Done()
+		}(clust, i, &wg)
+	}
+	wg.Wait()
+
+	return normalizeLog(logP)
+}
+
+// normalizeLog turns a slice of log probabilities into a proper probability
+// distribution using the log-sum-exp trick, which keeps the computation
+// stable even though every individual logP may correspond to a linear-space
+// probability that has already underflowed to 0.
+func normalizeLog(logP []float64) []float64 {
+	m := logP[0]
+	for _, v := range logP[1:] {
+		if v > m {
+			m = v
+		}
+	}
+
+	var sumExp float64
+	for _, v := range logP {
+		sumExp += math.Exp(v - m)
+	}
+	norm := m + math.Log(sumExp)
+
+	retVal := make([]float64, len(logP))
+	for i, v := range logP {
+		retVal[i] = math.Exp(v - norm)
+	}
+	return retVal
+}
+
+func algo3LogNumerator(clust Cluster, ts TokenSet, beta float64) float64 {
+	var retVal float64
+	for _, tok := range ts {
+		retVal += math.Log(clust.Freq(tok) + beta)
+	}
+	return retVal
+}
+
+func algo4LogNumerator(clust Cluster, ts TokenSet, beta float64) float64 {
+	d := make(kvs, 0, len(ts))
+	for _, tok := range ts {
+		d = d.add(tok)
+		d.incr(tok)
+	}
+
+	var retVal float64
+	for _, tok := range ts {
+		freq := d.val(tok)
+		clustFreq := clust.Freq(tok)
+		for j := 0.0; j < freq; j++ {
+			retVal += math.Log(clustFreq + beta + j)
+		}
+	}
+	return retVal
+}
+
+func algoLogDenominator(clust Cluster, ts TokenSet, beta float64, vocab float64) float64 {
+	var retVal float64
+	wc := float64(clust.Wordcount())
+	for i := 0; i < len(ts); i++ {
+		retVal += math.Log(wc + vocab*beta + float64(i))
+	}
 	return retVal
 }
 
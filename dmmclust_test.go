@@ -1,6 +1,10 @@
 package dmmclust
 
-import "testing"
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
 
 func TestCluster_Words(t *testing.T) {
 	c := Cluster{
@@ -17,7 +21,7 @@ func TestCluster_Words(t *testing.T) {
 func TestShitConfig(t *testing.T) {
 	confs := []Config{
 		{},
-		{Sample: Gibbs},
+		{Sampler: NewGibbs(rand.New(rand.NewSource(1)))},
 		{Score: Algorithm3},
 	}
 
@@ -27,3 +31,118 @@ func TestShitConfig(t *testing.T) {
 		}
 	}
 }
+
+// longDocs builds n documents of length docLen drawn from a vocabulary of
+// size vocab, long enough that Algorithm3/Algorithm4's raw-product score
+// underflows to 0. Pass a vocab larger than docLen to keep tokens from
+// repeating within a document.
+func longDocs(n, docLen, vocab int) []Document {
+	docs := make([]Document, n)
+	for i := range docs {
+		ts := make(TokenSet, docLen)
+		for j := range ts {
+			ts[j] = (i + j) % vocab
+		}
+		docs[i] = ts
+	}
+	return docs
+}
+
+// freshClusters returns k clusters with no documents assigned, which is
+// enough to reproduce the underflow: every token's frequency in an
+// as-yet-empty cluster is 0, so Algorithm3/Algorithm4's numerator is a
+// product of ~beta over hundreds of tokens.
+func freshClusters(k int) []Cluster {
+	return make([]Cluster, k)
+}
+
+func TestAlgorithm3Log(t *testing.T) {
+	const vocab = 600 // > docLen, so no token repeats within a document
+	conf := Config{K: 4, Vocabulary: vocab, Alpha: 0.0001, Beta: 0.1}
+
+	docs := longDocs(3, 500, vocab)
+	clusters := freshClusters(conf.K)
+
+	if s := sum(Algorithm3(docs[0], docs, clusters, conf)); s != 0 {
+		t.Fatalf("expected Algorithm3 to underflow to 0 on a 500-token document, got %v", s)
+	}
+
+	logSpace := Algorithm3Log(docs[0], docs, clusters, conf)
+	if s := sum(logSpace); math.Abs(s-1) > 1e-9 {
+		t.Errorf("expected Algorithm3Log scores to sum to 1, got %v (sum %v)", logSpace, s)
+	}
+	for i, p := range logSpace {
+		if p <= 0 {
+			t.Errorf("expected cluster %d to have a non-zero probability, got %v", i, p)
+		}
+	}
+}
+
+func TestAlgorithm4Log(t *testing.T) {
+	const vocab = 600 // > docLen, so no token repeats within a document
+	conf := Config{K: 4, Vocabulary: vocab, Alpha: 0.0001, Beta: 0.1}
+
+	docs := longDocs(3, 500, vocab)
+	clusters := freshClusters(conf.K)
+
+	if s := sum(Algorithm4(docs[0], docs, clusters, conf)); s != 0 {
+		t.Fatalf("expected Algorithm4 to underflow to 0 on a 500-token document, got %v", s)
+	}
+
+	logSpace := Algorithm4Log(docs[0], docs, clusters, conf)
+	if s := sum(logSpace); math.Abs(s-1) > 1e-9 {
+		t.Errorf("expected Algorithm4Log scores to sum to 1, got %v (sum %v)", logSpace, s)
+	}
+	for i, p := range logSpace {
+		if p <= 0 {
+			t.Errorf("expected cluster %d to have a non-zero probability, got %v", i, p)
+		}
+	}
+}
+
+// seededCluster returns a Cluster with docs already folded into it, so that
+// its word distribution is non-trivial - unlike freshClusters, which only
+// exercises the underflow guard.
+func seededCluster(docs ...Document) Cluster {
+	var c Cluster
+	for _, doc := range docs {
+		c.addDoc(doc)
+	}
+	return c
+}
+
+// TestAlgorithm3Log_Discriminates checks that, unlike the freshClusters case
+// above, Algorithm3Log actually uses the per-token word distribution to tell
+// clusters apart: a long document drawn entirely from one cluster's existing
+// vocabulary should score far higher against that cluster than against an
+// equally long one built from disjoint tokens.
+func TestAlgorithm3Log_Discriminates(t *testing.T) {
+	const vocab = 2000
+	conf := Config{K: 2, Vocabulary: vocab, Alpha: 0.0001, Beta: 0.1}
+
+	lo := longDocs(3, 500, 500) // tokens [0, 500)
+	hi := longDocs(3, 500, 500) // also tokens [0, 500) before the shift
+	for i, doc := range hi {
+		ts := doc.TokenSet()
+		for j := range ts {
+			ts[j] += 1000 // shift into the disjoint range [1000, 1500)
+		}
+		hi[i] = ts
+	}
+
+	clusters := []Cluster{
+		seededCluster(lo[1], lo[2]),
+		seededCluster(hi[1], hi[2]),
+	}
+	corpus := append(append([]Document{}, lo...), hi...) // only its length matters to Algorithm3Log
+
+	p := Algorithm3Log(lo[0], corpus, clusters, conf)
+	if p[0] <= p[1] {
+		t.Errorf("expected lo[0] to score higher against the matching-vocabulary cluster 0, got %v", p)
+	}
+
+	q := Algorithm3Log(hi[0], corpus, clusters, conf)
+	if q[1] <= q[0] {
+		t.Errorf("expected hi[0] to score higher against the matching-vocabulary cluster 1, got %v", q)
+	}
+}
@@ -0,0 +1,70 @@
+package dmmclust
+
+import "math"
+
+// estimateAlpha re-estimates Alpha from the current cluster assignment using
+// Minka's fixed-point iteration for the Dirichlet-multinomial concentration
+// parameter, considering only non-empty clusters.
+func estimateAlpha(state []Cluster, alpha float64, n int) float64 {
+	var numer float64
+	var k int
+	for i := range state {
+		nk := state[i].Docs()
+		if nk == 0 {
+			continue
+		}
+		numer += digamma(float64(nk)+alpha) - digamma(alpha)
+		k++
+	}
+	if k == 0 {
+		return alpha
+	}
+
+	kAlpha := float64(k) * alpha
+	denom := float64(k) * (digamma(float64(n)+kAlpha) - digamma(kAlpha))
+	if denom == 0 {
+		return alpha
+	}
+	return alpha * numer / denom
+}
+
+// estimateBeta re-estimates Beta analogously to estimateAlpha, but over the
+// per-cluster word frequencies rather than document counts, considering only
+// non-empty clusters and words with a nonzero count.
+func estimateBeta(state []Cluster, beta float64, vocab float64) float64 {
+	var numer, denom float64
+	for i := range state {
+		nkWords := state[i].Wordcount()
+		if nkWords == 0 {
+			continue
+		}
+		for _, freq := range state[i].dist {
+			if freq == 0 {
+				continue
+			}
+			numer += digamma(freq+beta) - digamma(beta)
+		}
+		denom += digamma(float64(nkWords)+vocab*beta) - digamma(vocab*beta)
+	}
+	if denom == 0 {
+		return beta
+	}
+	return beta * numer / (vocab * denom)
+}
+
+// digamma approximates the digamma function (the logarithmic derivative of
+// the gamma function), using the recurrence relation to shift small
+// arguments up into the range where the asymptotic expansion below is
+// accurate.
+func digamma(x float64) float64 {
+	var result float64
+	for x < 6 {
+		result -= 1 / x
+		x++
+	}
+
+	f := 1 / (x * x)
+	result += math.Log(x) - 0.5/x -
+		f*(1.0/12-f*(1.0/120-f*(1.0/252-f*(1.0/240-f*(1.0/132)))))
+	return result
+}
@@ -0,0 +1,61 @@
+package dmmclust
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDigamma(t *testing.T) {
+	// known values, see e.g. https://en.wikipedia.org/wiki/Digamma_function
+	cases := map[float64]float64{
+		1: -0.5772156649,
+		2: 0.4227843351,
+		3: 0.9227843351,
+	}
+	for x, want := range cases {
+		if got := digamma(x); math.Abs(got-want) > 1e-6 {
+			t.Errorf("digamma(%v): expected %v, got %v", x, want, got)
+		}
+	}
+}
+
+func TestEstimateAlphaBeta(t *testing.T) {
+	state := []Cluster{
+		{docs: 8, words: 24, dist: distro{0: 8, 1: 8, 2: 8}},
+		{docs: 2, words: 6, dist: distro{0: 2, 3: 2, 4: 2}},
+		{}, // empty cluster, should be ignored
+	}
+
+	alpha := estimateAlpha(state, 0.0001, 10)
+	if alpha <= 0 {
+		t.Errorf("expected a positive re-estimated alpha, got %v", alpha)
+	}
+
+	beta := estimateBeta(state, 0.1, 5)
+	if beta <= 0 {
+		t.Errorf("expected a positive re-estimated beta, got %v", beta)
+	}
+}
+
+func TestFit_EstimateHyperparams(t *testing.T) {
+	conf := makeStreamConf(4, 10)
+	conf.EstimateHyperparams = true
+	docs := []Document{
+		TokenSet{0, 1, 2},
+		TokenSet{0, 1, 3},
+		TokenSet{7, 8, 9},
+		TokenSet{7, 8, 6},
+	}
+
+	m, _, err := Fit(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Alpha() <= 0 || math.IsNaN(m.Alpha()) || math.IsInf(m.Alpha(), 0) {
+		t.Errorf("expected a finite, positive estimated Alpha, got %v", m.Alpha())
+	}
+	if m.Beta() <= 0 || math.IsNaN(m.Beta()) || math.IsInf(m.Beta(), 0) {
+		t.Errorf("expected a finite, positive estimated Beta, got %v", m.Beta())
+	}
+}
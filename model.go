@@ -0,0 +1,211 @@
+package dmmclust
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+)
+
+// Model is a fitted clustering model. Unlike the []Cluster returned by
+// FindClusters, a Model retains the sufficient statistics (the per-cluster
+// word distributions and the document-to-cluster assignments) it was fitted
+// with, so that documents arriving after the initial fit can be assigned to
+// a cluster, or incorporated into the model, without refitting the whole
+// corpus from scratch.
+type Model struct {
+	state []Cluster
+	dz    []int
+	conf  Config
+	vocab int
+	n     int // number of documents folded into state so far
+}
+
+// NewModel creates an empty Model configured with conf. It is intended to be
+// used together with UnmarshalBinary to reload a model that was previously
+// persisted with MarshalBinary.
+func NewModel(conf Config) *Model {
+	return &Model{conf: conf, vocab: conf.Vocabulary}
+}
+
+// Fit behaves like FindClusters - it Gibbs-samples docs for conf.Iter
+// iterations - but additionally returns a Model that retains the fitted
+// state, so that new documents can later be handed to Assign, Update or
+// Partial instead of requiring a full refit.
+func Fit(docs []Document, conf Config) (*Model, []Cluster, error) {
+	state, dz, finalConf, err := fit(docs, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := &Model{
+		state: state,
+		dz:    dz,
+		conf:  finalConf,
+		vocab: finalConf.Vocabulary,
+		n:     len(docs),
+	}
+	return m, relabel(state, dz, finalConf.K), nil
+}
+
+// Clusters returns the model's current clustering, relabelled the same way
+// FindClusters/Fit label their results.
+func (m *Model) Clusters() []Cluster {
+	return relabel(m.state, m.dz, m.conf.K)
+}
+
+// Alpha returns the document-concentration hyperparameter currently in use.
+// If Config.EstimateHyperparams was set, this is the final fixed-point
+// estimate rather than the value originally passed in via Config.
+func (m *Model) Alpha() float64 { return m.conf.Alpha }
+
+// Beta returns the word-concentration hyperparameter currently in use,
+// analogous to Alpha.
+func (m *Model) Beta() float64 { return m.conf.Beta }
+
+// label returns the externally visible ID for internal cluster index z,
+// renumbered the same way relabel numbers the IDs returned by Clusters()/
+// FindClusters - in order of first appearance in the model's document
+// assignments. If z hasn't appeared in m.dz yet, it is given the next unused
+// label, i.e. the label it would get were a document assigned to it right
+// now.
+func (m *Model) label(z int) int {
+	reindex := clusterLabels(m.dz, m.conf.K)
+	if id := reindex[z]; id >= 0 {
+		return id
+	}
+	var maxID int
+	for _, id := range reindex {
+		if id >= maxID {
+			maxID = id + 1
+		}
+	}
+	return maxID
+}
+
+// Assign scores doc against the model's current state and returns the
+// cluster it would be assigned to, with an ID in the same namespace as
+// Clusters()/FindClusters. It is a single-pass operation - doc is not added
+// to the model's sufficient statistics, so calling Assign repeatedly with
+// the same document has no cumulative effect. Use Update to additionally
+// incorporate doc into the model.
+func (m *Model) Assign(doc Document) Cluster {
+	docs := make([]Document, m.n+1)
+	p := m.conf.Score(doc, docs, m.state, m.conf)
+	z := m.conf.Sampler.Sample(p)
+
+	c := m.state[z]
+	c.id = m.label(z)
+	return c
+}
+
+// Update scores doc exactly as Assign does, but additionally folds it into
+// the sampled cluster's sufficient statistics and records its assignment, so
+// that it is accounted for in subsequent calls to Assign, Update, Partial or
+// Clusters.
+func (m *Model) Update(doc Document) Cluster {
+	docs := make([]Document, m.n+1)
+	p := m.conf.Score(doc, docs, m.state, m.conf)
+	z := m.conf.Sampler.Sample(p)
+
+	m.state[z].addDoc(doc)
+	m.dz = append(m.dz, z)
+	m.n++
+
+	c := m.state[z]
+	c.id = m.label(z)
+	return c
+}
+
+// Partial runs iter additional Gibbs sweeps over docs, a batch of newly
+// arrived documents, folding them into the model's existing sufficient
+// statistics rather than refitting the whole corpus. docs are first added to
+// the model (as Update would), then resampled in place for iter-1 further
+// sweeps.
+func (m *Model) Partial(docs []Document, iter int) {
+	start := len(m.dz)
+	for _, doc := range docs {
+		p := m.conf.Score(doc, make([]Document, m.n+1), m.state, m.conf)
+		z := m.conf.Sampler.Sample(p)
+
+		m.state[z].addDoc(doc)
+		m.dz = append(m.dz, z)
+		m.n++
+	}
+
+	for i := 1; i < iter; i++ {
+		for j, doc := range docs {
+			idx := start + j
+			old := m.dz[idx]
+			m.state[old].removeDoc(doc)
+
+			p := m.conf.Score(doc, make([]Document, m.n), m.state, m.conf)
+			z2 := m.conf.Sampler.Sample(p)
+
+			m.dz[idx] = z2
+			m.state[z2].addDoc(doc)
+		}
+	}
+}
+
+// modelSnapshot is the gob-encodable representation of a Model's sufficient
+// statistics. Config is deliberately excluded: Score and Sampler are
+// functions/interfaces and cannot be serialized, so callers reattach an
+// equivalent Config via NewModel before calling UnmarshalBinary.
+type modelSnapshot struct {
+	Docs  []int
+	Words []int
+	Dist  []distro
+	Dz    []int
+	Vocab int
+}
+
+// MarshalBinary serializes the model's sufficient statistics - the
+// per-cluster word distributions and document counts, and the
+// document-to-cluster assignments - using encoding/gob.
+func (m *Model) MarshalBinary() ([]byte, error) {
+	snap := modelSnapshot{
+		Docs:  make([]int, len(m.state)),
+		Words: make([]int, len(m.state)),
+		Dist:  make([]distro, len(m.state)),
+		Dz:    m.dz,
+		Vocab: m.vocab,
+	}
+	for i := range m.state {
+		snap.Docs[i] = m.state[i].docs
+		snap.Words[i] = m.state[i].words
+		snap.Dist[i] = m.state[i].dist
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return nil, errors.Wrap(err, "Unable to encode Model")
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores the sufficient statistics written by
+// MarshalBinary. The receiver should already have a Config set (typically by
+// constructing it with NewModel first) so that Score and Sampler are
+// available for subsequent Assign/Update/Partial calls.
+func (m *Model) UnmarshalBinary(data []byte) error {
+	var snap modelSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap); err != nil {
+		return errors.Wrap(err, "Unable to decode Model")
+	}
+
+	state := make([]Cluster, len(snap.Docs))
+	for i := range state {
+		state[i] = Cluster{
+			id:    i,
+			docs:  snap.Docs[i],
+			words: snap.Words[i],
+			dist:  snap.Dist[i],
+		}
+	}
+
+	m.state = state
+	m.dz = snap.Dz
+	m.vocab = snap.Vocab
+	m.n = len(snap.Dz)
+	return nil
+}
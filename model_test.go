@@ -0,0 +1,133 @@
+package dmmclust
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func makeStreamConf(k, vocab int) Config {
+	return Config{
+		K:          k,
+		Vocabulary: vocab,
+		Iter:       50,
+		Alpha:      0.0001,
+		Beta:       0.1,
+		Score:      Algorithm3,
+		Sampler:    NewGibbs(rand.New(rand.NewSource(42))),
+	}
+}
+
+func TestFit(t *testing.T) {
+	conf := makeStreamConf(4, 10)
+	docs := []Document{
+		TokenSet{0, 1, 2},
+		TokenSet{0, 1, 3},
+		TokenSet{7, 8, 9},
+		TokenSet{7, 8, 6},
+	}
+
+	m, clustered, err := Fit(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clustered) != len(docs) {
+		t.Fatalf("expected %d clustered documents, got %d", len(docs), len(clustered))
+	}
+	if len(m.Clusters()) != len(docs) {
+		t.Errorf("expected Model.Clusters() to also return %d documents, got %d", len(docs), len(m.Clusters()))
+	}
+}
+
+func TestModel_AssignUpdate(t *testing.T) {
+	conf := makeStreamConf(4, 10)
+	docs := []Document{
+		TokenSet{0, 1, 2},
+		TokenSet{0, 1, 3},
+		TokenSet{7, 8, 9},
+		TokenSet{7, 8, 6},
+	}
+
+	m, _, err := Fit(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDoc := TokenSet{0, 1, 4}
+	before := m.Clusters()
+	c := m.Assign(newDoc)
+	if c.ID() < 0 {
+		t.Errorf("expected a valid cluster ID, got %d", c.ID())
+	}
+	after := m.Clusters()
+	if len(before) != len(after) {
+		t.Errorf("Assign should not mutate the model's sufficient statistics")
+	}
+
+	updated := m.Update(newDoc)
+	if updated.ID() < 0 {
+		t.Errorf("expected a valid cluster ID, got %d", updated.ID())
+	}
+	if len(m.Clusters()) != len(docs)+1 {
+		t.Errorf("Update should add the document to the model, expected %d documents, got %d", len(docs)+1, len(m.Clusters()))
+	}
+}
+
+func TestModel_Partial(t *testing.T) {
+	conf := makeStreamConf(4, 10)
+	docs := []Document{
+		TokenSet{0, 1, 2},
+		TokenSet{0, 1, 3},
+		TokenSet{7, 8, 9},
+		TokenSet{7, 8, 6},
+	}
+
+	m, _, err := Fit(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDocs := []Document{
+		TokenSet{0, 1, 5},
+		TokenSet{7, 8, 2},
+	}
+	m.Partial(newDocs, 3)
+
+	if len(m.Clusters()) != len(docs)+len(newDocs) {
+		t.Errorf("expected %d documents after Partial, got %d", len(docs)+len(newDocs), len(m.Clusters()))
+	}
+}
+
+func TestModel_MarshalUnmarshalBinary(t *testing.T) {
+	conf := makeStreamConf(4, 10)
+	docs := []Document{
+		TokenSet{0, 1, 2},
+		TokenSet{0, 1, 3},
+		TokenSet{7, 8, 9},
+		TokenSet{7, 8, 6},
+	}
+
+	m, _, err := Fit(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewModel(conf)
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := m.Clusters(), loaded.Clusters()
+	if len(want) != len(got) {
+		t.Fatalf("expected %d documents after reload, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if want[i].ID() != got[i].ID() || want[i].Docs() != got[i].Docs() || want[i].Wordcount() != got[i].Wordcount() {
+			t.Errorf("document %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
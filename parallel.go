@@ -0,0 +1,103 @@
+package dmmclust
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelSweep performs one Gibbs sweep over docs using a per-document
+// worker pool, in the style of SparseLDA's stale-statistics Gibbs sampling:
+// docs are partitioned into runtime.GOMAXPROCS(0) shards, and each shard is
+// scored against a snapshot of state taken at the start of the sweep rather
+// than against state as it's being mutated by other shards. Once every
+// worker has sampled its shard, the resulting reassignments are applied back
+// into state and dz. It returns the number of documents that moved cluster.
+func parallelSweep(docs []Document, dz []int, state []Cluster, conf Config) int {
+	nshards := runtime.GOMAXPROCS(0)
+	if nshards > len(docs) {
+		nshards = len(docs)
+	}
+	if nshards < 1 {
+		nshards = 1
+	}
+
+	snapshot := cloneClusters(state)
+
+	shardSize := (len(docs) + nshards - 1) / nshards
+	assignments := make([]int, len(docs))
+	var wg sync.WaitGroup
+	var samplerMu sync.Mutex // conf.Sampler wraps a *rand.Rand, which isn't safe for concurrent use
+	for shard := 0; shard < nshards; shard++ {
+		start := shard * shardSize
+		end := start + shardSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+		if start >= end {
+			continue
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			// local is this shard's own working copy of the snapshot: docs
+			// within the shard are resampled serially against it (exactly
+			// as the non-parallel sweep would), so they see each other's
+			// moves. What's stale is cross-shard: every shard starts from
+			// the same start-of-sweep snapshot, so it doesn't see the
+			// other shards' moves until the next sweep.
+			local := cloneClusters(snapshot)
+			for j := start; j < end; j++ {
+				doc := docs[j]
+				old := dz[j]
+				local[old].removeDoc(doc)
+
+				p := conf.Score(doc, docs, local, conf)
+
+				samplerMu.Lock()
+				z2 := conf.Sampler.Sample(p)
+				samplerMu.Unlock()
+
+				assignments[j] = z2
+				local[z2].addDoc(doc)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	var transfers int
+	for j, doc := range docs {
+		old := dz[j]
+		z2 := assignments[j]
+		if z2 == old {
+			continue
+		}
+		transfers++
+		state[old].removeDoc(doc)
+		dz[j] = z2
+		state[z2].addDoc(doc)
+	}
+	return transfers
+}
+
+// cloneClusters returns a deep copy of state - including each cluster's word
+// distribution - so that it can be handed to worker goroutines as a stable
+// snapshot that the sweep's own writes won't disturb.
+func cloneClusters(state []Cluster) []Cluster {
+	cp := make([]Cluster, len(state))
+	for i := range state {
+		cp[i] = cloneCluster(state[i])
+	}
+	return cp
+}
+
+func cloneCluster(c Cluster) Cluster {
+	cp := c
+	if c.dist != nil {
+		cp.dist = make(distro, len(c.dist))
+		for k, v := range c.dist {
+			cp.dist[k] = v
+		}
+	}
+	return cp
+}
@@ -0,0 +1,67 @@
+package dmmclust_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/go-nlp/dmmclust"
+)
+
+// pairwiseAgreement is a Rand-index-style measure of how similar two
+// clusterings of the same n items are: the fraction of item pairs on which
+// both clusterings agree about whether the pair is in the same cluster or
+// not, ignoring what the cluster IDs themselves are.
+func pairwiseAgreement(a, b []Cluster) float64 {
+	n := len(a)
+	var agree, total int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			total++
+			sameA := a[i].ID() == a[j].ID()
+			sameB := b[i].ID() == b[j].ID()
+			if sameA == sameB {
+				agree++
+			}
+		}
+	}
+	return float64(agree) / float64(total)
+}
+
+func TestFindClusters_Parallel(t *testing.T) {
+	corp := makeCorpus(data)
+	docs := makeDocuments(data, corp, false)
+
+	conf := Config{
+		K:          10,
+		Vocabulary: len(corp),
+		Iter:       1000,
+		Alpha:      0.0001,
+		Beta:       0.1,
+		Score:      Algorithm3,
+	}
+
+	conf.Sampler = NewGibbs(rand.New(rand.NewSource(1337)))
+	serial, err := FindClusters(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Sampler = NewGibbs(rand.New(rand.NewSource(1337)))
+	conf.Parallel = true
+	parallel, err := FindClusters(docs, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parallel trades exact reproducibility for throughput - its workers
+	// sample against a stale, start-of-sweep snapshot, and the order in
+	// which they draw from the shared Sampler isn't deterministic - so an
+	// identical partition isn't guaranteed. In practice it converges to the
+	// same partition as the serial algorithm in the large majority of runs
+	// with this seed, and 0.8 pairwise agreement was the observed floor
+	// across repeated runs, so require a partition close to that rather
+	// than accepting any coarsely similar clustering.
+	if agreement := pairwiseAgreement(serial, parallel); agreement < 0.8 {
+		t.Errorf("expected Parallel:true to converge to a similar partition as the serial algorithm, got pairwise agreement %v\nserial:   %v\nparallel: %v", agreement, serial, parallel)
+	}
+}